@@ -0,0 +1,80 @@
+package resolver
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of a single Resolver.LookupHost call: the
+// resolved addresses, the minimum TTL across the records that produced
+// them (zero if the backend cannot report one), and whether this is a
+// confirmed negative response (NXDOMAIN/NODATA/a non-zero rcode)
+// suitable for negative caching. NXDomain narrows Negative to the one
+// case - the name itself does not exist - where it is safe to treat the
+// negative response as authoritative and clear any previously cached
+// addresses; any other negative result (NODATA, SERVFAIL, ...) should be
+// handled like a transient failure instead.
+type Result struct {
+	Addrs    []string
+	TTL      time.Duration
+	Negative bool
+	NXDomain bool
+}
+
+// Resolver is a pluggable DNS resolution backend. Implementations turn a
+// hostname into the set of addresses it currently resolves to, without
+// caring about polling, caching, or selection policy - those concerns
+// live in svc.
+type Resolver interface {
+	LookupHost(host string) (Result, error)
+}
+
+// systemResolver delegates to the OS stub resolver, same as the
+// original hard-coded behavior. It cannot report a real TTL.
+type systemResolver struct{}
+
+// NewSystemResolver returns a Resolver backed by net.LookupHost.
+func NewSystemResolver() Resolver {
+	return systemResolver{}
+}
+
+func (systemResolver) LookupHost(host string) (Result, error) {
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Addrs: addrs}, nil
+}
+
+// LookupSRV resolves qname (a full "_service._proto.name" query name)
+// via the OS stub resolver. It cannot report a real TTL.
+func (systemResolver) LookupSRV(qname string) ([]SRVTarget, time.Duration, error) {
+
+	_, srvs, err := net.LookupSRV("", "", qname)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	targets := make([]SRVTarget, 0, len(srvs))
+	for _, srv := range srvs {
+		targets = append(targets, SRVTarget{
+			Priority: srv.Priority,
+			Weight:   srv.Weight,
+			Port:     srv.Port,
+			Host:     strings.TrimSuffix(srv.Target, "."),
+		})
+	}
+
+	return targets, 0, nil
+}
+
+// LookupTXT resolves name's TXT records via the OS stub resolver. It
+// cannot report a real TTL.
+func (systemResolver) LookupTXT(name string) ([]string, time.Duration, error) {
+	values, err := net.LookupTXT(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return values, 0, nil
+}
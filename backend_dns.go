@@ -0,0 +1,166 @@
+package resolver
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// dnsResolver issues plain DNS queries against a specific upstream
+// server, over UDP with a fallback to TCP on truncation.
+type dnsResolver struct {
+	server  string // host:port
+	network string // "udp" or "tcp", "" means "udp with tcp fallback"
+	timeout time.Duration
+}
+
+// NewDNSResolver returns a Resolver that queries server (host:port)
+// directly instead of going through the system stub resolver.
+func NewDNSResolver(server string) Resolver {
+	return &dnsResolver{server: server, timeout: 5 * time.Second}
+}
+
+// NewTCPDNSResolver is like NewDNSResolver but always uses TCP.
+func NewTCPDNSResolver(server string) Resolver {
+	return &dnsResolver{server: server, network: "tcp", timeout: 5 * time.Second}
+}
+
+func (r *dnsResolver) LookupHost(host string) (Result, error) {
+
+	a, err := resolveWithCNAME(host, dnsTypeA, r.query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	aaaa, err := resolveWithCNAME(host, dnsTypeAAAA, r.query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return mergeAnswers(a, aaaa), nil
+}
+
+// LookupSRV resolves the SRV records for qname against r.server.
+func (r *dnsResolver) LookupSRV(qname string) ([]SRVTarget, time.Duration, error) {
+
+	query, id := encodeQuery(qname, dnsTypeSRV)
+
+	resp, err := r.exchangeFollowingTruncation(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	targets, ttl, negative, err := decodeSRVAnswer(resp, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if negative {
+		return nil, ttl, nil
+	}
+
+	return targets, ttl, nil
+}
+
+// LookupTXT resolves the TXT records for name against r.server.
+func (r *dnsResolver) LookupTXT(name string) ([]string, time.Duration, error) {
+
+	query, id := encodeQuery(name, dnsTypeTXT)
+
+	resp, err := r.exchangeFollowingTruncation(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values, ttl, negative, err := decodeTXTAnswer(resp, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if negative {
+		return nil, ttl, nil
+	}
+
+	return values, ttl, nil
+}
+
+func (r *dnsResolver) exchangeFollowingTruncation(query []byte) ([]byte, error) {
+
+	network := r.network
+	if network == "" {
+		network = "udp"
+	}
+
+	resp, err := r.exchange(network, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if network == "udp" && len(resp) > 2 && resp[2]&0x02 != 0 {
+		return r.exchange("tcp", query)
+	}
+
+	return resp, nil
+}
+
+func (r *dnsResolver) query(host string, qtype uint16) (answer, error) {
+
+	query, id := encodeQuery(host, qtype)
+
+	resp, err := r.exchangeFollowingTruncation(query)
+	if err != nil {
+		return answer{}, err
+	}
+
+	return decodeAnswer(resp, id, qtype)
+}
+
+func (r *dnsResolver) exchange(network string, query []byte) ([]byte, error) {
+
+	conn, err := net.DialTimeout(network, r.server, r.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("resolver: dial %s: %w", r.server, err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(r.timeout))
+
+	if network == "tcp" {
+		length := []byte{byte(len(query) >> 8), byte(len(query))}
+		if _, err := conn.Write(append(length, query...)); err != nil {
+			return nil, err
+		}
+
+		lenBuf := make([]byte, 2)
+		if _, err := readFull(conn, lenBuf); err != nil {
+			return nil, err
+		}
+		msg := make([]byte, int(lenBuf[0])<<8|int(lenBuf[1]))
+		if _, err := readFull(conn, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+func readFull(conn net.Conn, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := conn.Read(buf[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
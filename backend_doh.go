@@ -0,0 +1,118 @@
+package resolver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const dnsMessageContentType = "application/dns-message"
+
+// dohResolver issues DNS queries over HTTPS (RFC 8484), POSTing the raw
+// wire-format message to endpointURL.
+type dohResolver struct {
+	endpointURL string
+	client      *http.Client
+}
+
+// NewDoHResolver returns a Resolver that queries endpointURL
+// (e.g. "https://dns.example.com/dns-query") over DNS-over-HTTPS.
+func NewDoHResolver(endpointURL string) Resolver {
+	return &dohResolver{
+		endpointURL: endpointURL,
+		client:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (r *dohResolver) LookupHost(host string) (Result, error) {
+
+	a, err := resolveWithCNAME(host, dnsTypeA, r.query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	aaaa, err := resolveWithCNAME(host, dnsTypeAAAA, r.query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return mergeAnswers(a, aaaa), nil
+}
+
+func (r *dohResolver) query(host string, qtype uint16) (answer, error) {
+
+	query, id := encodeQuery(host, qtype)
+
+	msg, err := r.exchange(query)
+	if err != nil {
+		return answer{}, err
+	}
+
+	return decodeAnswer(msg, id, qtype)
+}
+
+// LookupSRV resolves the SRV records for qname over DNS-over-HTTPS.
+func (r *dohResolver) LookupSRV(qname string) ([]SRVTarget, time.Duration, error) {
+
+	query, id := encodeQuery(qname, dnsTypeSRV)
+
+	msg, err := r.exchange(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	targets, ttl, negative, err := decodeSRVAnswer(msg, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if negative {
+		return nil, ttl, nil
+	}
+
+	return targets, ttl, nil
+}
+
+// LookupTXT resolves the TXT records for name over DNS-over-HTTPS.
+func (r *dohResolver) LookupTXT(name string) ([]string, time.Duration, error) {
+
+	query, id := encodeQuery(name, dnsTypeTXT)
+
+	msg, err := r.exchange(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values, ttl, negative, err := decodeTXTAnswer(msg, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if negative {
+		return nil, ttl, nil
+	}
+
+	return values, ttl, nil
+}
+
+func (r *dohResolver) exchange(query []byte) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodPost, r.endpointURL, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dnsMessageContentType)
+	req.Header.Set("Accept", dnsMessageContentType)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("resolver: doh request to %s: %w", r.endpointURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("resolver: doh %s returned status %d", r.endpointURL, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
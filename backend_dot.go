@@ -0,0 +1,126 @@
+package resolver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// dotResolver issues DNS queries over a TLS-wrapped TCP connection
+// (DNS-over-TLS, RFC 7858).
+type dotResolver struct {
+	server     string // host:port, typically ":853"
+	serverName string // for certificate verification, defaults to host
+	timeout    time.Duration
+}
+
+// NewDoTResolver returns a Resolver that queries server (host:port) over
+// DNS-over-TLS. serverName is used for TLS certificate verification; if
+// empty, the host portion of server is used.
+func NewDoTResolver(server, serverName string) Resolver {
+	return &dotResolver{
+		server:     server,
+		serverName: serverName,
+		timeout:    5 * time.Second,
+	}
+}
+
+func (r *dotResolver) LookupHost(host string) (Result, error) {
+
+	a, err := resolveWithCNAME(host, dnsTypeA, r.query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	aaaa, err := resolveWithCNAME(host, dnsTypeAAAA, r.query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return mergeAnswers(a, aaaa), nil
+}
+
+func (r *dotResolver) query(host string, qtype uint16) (answer, error) {
+
+	query, id := encodeQuery(host, qtype)
+
+	msg, err := r.exchange(query)
+	if err != nil {
+		return answer{}, err
+	}
+
+	return decodeAnswer(msg, id, qtype)
+}
+
+// LookupSRV resolves the SRV records for qname over DNS-over-TLS.
+func (r *dotResolver) LookupSRV(qname string) ([]SRVTarget, time.Duration, error) {
+
+	query, id := encodeQuery(qname, dnsTypeSRV)
+
+	msg, err := r.exchange(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	targets, ttl, negative, err := decodeSRVAnswer(msg, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if negative {
+		return nil, ttl, nil
+	}
+
+	return targets, ttl, nil
+}
+
+// LookupTXT resolves the TXT records for name over DNS-over-TLS.
+func (r *dotResolver) LookupTXT(name string) ([]string, time.Duration, error) {
+
+	query, id := encodeQuery(name, dnsTypeTXT)
+
+	msg, err := r.exchange(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values, ttl, negative, err := decodeTXTAnswer(msg, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if negative {
+		return nil, ttl, nil
+	}
+
+	return values, ttl, nil
+}
+
+func (r *dotResolver) exchange(query []byte) ([]byte, error) {
+
+	tlsConf := &tls.Config{ServerName: r.serverName}
+	dialer := &net.Dialer{Timeout: r.timeout}
+
+	conn, err := tls.DialWithDialer(dialer, "tcp", r.server, tlsConf)
+	if err != nil {
+		return nil, fmt.Errorf("resolver: dot dial %s: %w", r.server, err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(r.timeout))
+
+	length := []byte{byte(len(query) >> 8), byte(len(query))}
+	if _, err := conn.Write(append(length, query...)); err != nil {
+		return nil, err
+	}
+
+	lenBuf := make([]byte, 2)
+	if _, err := readFull(conn, lenBuf); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, int(lenBuf[0])<<8|int(lenBuf[1]))
+	if _, err := readFull(conn, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,483 @@
+package resolver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Minimal DNS wire-format (RFC 1035) encode/decode, just enough to issue
+// an A or AAAA query and read back the answer addresses and their TTLs.
+// Shared by the plain UDP/TCP, DoT and DoH backends so none of them need
+// an external DNS library.
+
+const (
+	dnsTypeA     = 1
+	dnsTypeCNAME = 5
+	dnsTypeSOA   = 6
+	dnsTypeTXT   = 16
+	dnsTypeAAAA  = 28
+	dnsTypeSRV   = 33
+	dnsClassIN   = 1
+
+	dnsRcodeNXDomain = 3
+)
+
+var errDNSShortMessage = errors.New("resolver: short dns message")
+
+// answer is the result of decoding a single-question DNS response: the
+// matching A/AAAA addresses and the minimum TTL across them, or - for a
+// negative response (NXDOMAIN/NODATA) - the SOA minimum to use for
+// negative caching. nxdomain narrows negative to a confirmed NXDOMAIN,
+// as opposed to NODATA or some other non-zero rcode. cname is set when
+// the answer carried a CNAME for the question name instead of (or
+// alongside) a matching address, so the caller can follow it explicitly.
+type answer struct {
+	addrs    []string
+	ttl      time.Duration
+	negative bool
+	nxdomain bool
+	cname    string
+}
+
+// encodeQuery builds a single-question DNS query for host/qtype.
+func encodeQuery(host string, qtype uint16) ([]byte, uint16) {
+
+	id := uint16(rand.Intn(1 << 16))
+
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint16(buf[0:], id)
+	buf[2] = 0x01                          // RD (recursion desired)
+	binary.BigEndian.PutUint16(buf[4:], 1) // QDCOUNT
+
+	buf = append(buf, encodeName(host)...)
+
+	qtb := make([]byte, 4)
+	binary.BigEndian.PutUint16(qtb[0:], qtype)
+	binary.BigEndian.PutUint16(qtb[2:], dnsClassIN)
+	buf = append(buf, qtb...)
+
+	return buf, id
+}
+
+func encodeName(host string) []byte {
+
+	host = strings.TrimSuffix(host, ".")
+
+	var buf []byte
+	for _, label := range strings.Split(host, ".") {
+		buf = append(buf, byte(len(label)))
+		buf = append(buf, label...)
+	}
+	buf = append(buf, 0)
+
+	return buf
+}
+
+// decodeAnswer parses a DNS response message, validates the transaction
+// id, and returns the A/AAAA addresses plus the minimum TTL across the
+// matched records. For a negative response it returns the SOA minimum
+// (if present) so the caller can apply negative caching.
+func decodeAnswer(msg []byte, id uint16, qtype uint16) (answer, error) {
+
+	off, ancount, nscount, rcode, err := decodeHeader(msg, id)
+	if err != nil {
+		return answer{}, err
+	}
+
+	var addrs []string
+	var minTTL uint32
+	var cname string
+	haveTTL := false
+
+	for i := 0; i < ancount; i++ {
+		rtype, ttl, rdata, rdataOff, next, err := readRR(msg, off)
+		if err != nil {
+			return answer{}, err
+		}
+		off = next
+
+		switch {
+		case rtype == qtype:
+			if !haveTTL || ttl < minTTL {
+				minTTL = ttl
+				haveTTL = true
+			}
+			switch rtype {
+			case dnsTypeA:
+				if len(rdata) == 4 {
+					addrs = append(addrs, fmt.Sprintf("%d.%d.%d.%d",
+						rdata[0], rdata[1], rdata[2], rdata[3]))
+				}
+			case dnsTypeAAAA:
+				if len(rdata) == 16 {
+					addrs = append(addrs, formatIPv6(rdata))
+				}
+			}
+		case rtype == dnsTypeCNAME && cname == "":
+			target, _, err := decodeName(msg, rdataOff)
+			if err == nil {
+				cname = target
+			}
+		}
+	}
+
+	if len(addrs) > 0 {
+		return answer{addrs: addrs, ttl: time.Duration(minTTL) * time.Second}, nil
+	}
+
+	if cname != "" {
+		return answer{cname: cname}, nil
+	}
+
+	// No usable answers: NXDOMAIN, NODATA, or a non-zero rcode. Look
+	// for a SOA in the authority section to drive negative caching.
+	if soaTTL, ok := findSOAMinimum(msg, off, nscount); ok {
+		return answer{negative: true, nxdomain: rcode == dnsRcodeNXDomain, ttl: soaTTL}, nil
+	}
+
+	if rcode == dnsRcodeNXDomain {
+		return answer{negative: true, nxdomain: true}, nil
+	}
+
+	if rcode != 0 {
+		return answer{negative: true}, nil
+	}
+
+	return answer{}, nil
+}
+
+// findSOAMinimum scans nscount authority records starting at off for a
+// SOA record and, if found, returns its MINIMUM field as a TTL.
+func findSOAMinimum(msg []byte, off int, nscount int) (time.Duration, bool) {
+
+	for i := 0; i < nscount; i++ {
+		rtype, _, rdata, _, next, err := readRR(msg, off)
+		if err != nil {
+			return 0, false
+		}
+		off = next
+
+		if rtype == dnsTypeSOA {
+			if soaMin, ok := soaMinimum(rdata); ok {
+				return time.Duration(soaMin) * time.Second, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// decodeSRVAnswer parses a DNS response to an SRV query into its
+// targets and their minimum TTL, or a negative/SOA result as per
+// decodeAnswer.
+func decodeSRVAnswer(msg []byte, id uint16) ([]SRVTarget, time.Duration, bool, error) {
+
+	off, ancount, nscount, rcode, err := decodeHeader(msg, id)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	var targets []SRVTarget
+	var minTTL uint32
+	haveTTL := false
+
+	for i := 0; i < ancount; i++ {
+		rtype, ttl, rdata, rdataOff, next, err := readRR(msg, off)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		off = next
+
+		if rtype != dnsTypeSRV || len(rdata) < 6 {
+			continue
+		}
+
+		target, _, err := decodeName(msg, rdataOff+6)
+		if err != nil {
+			continue
+		}
+
+		targets = append(targets, SRVTarget{
+			Priority: binary.BigEndian.Uint16(rdata[0:]),
+			Weight:   binary.BigEndian.Uint16(rdata[2:]),
+			Port:     binary.BigEndian.Uint16(rdata[4:]),
+			Host:     target,
+		})
+
+		if !haveTTL || ttl < minTTL {
+			minTTL = ttl
+			haveTTL = true
+		}
+	}
+
+	if len(targets) > 0 {
+		return targets, time.Duration(minTTL) * time.Second, false, nil
+	}
+
+	if soaTTL, ok := findSOAMinimum(msg, off, nscount); ok {
+		return nil, soaTTL, true, nil
+	}
+
+	return nil, 0, rcode != 0, nil
+}
+
+// decodeTXTAnswer parses a DNS response to a TXT query into its
+// character-string values and their minimum TTL.
+func decodeTXTAnswer(msg []byte, id uint16) ([]string, time.Duration, bool, error) {
+
+	off, ancount, nscount, rcode, err := decodeHeader(msg, id)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	var values []string
+	var minTTL uint32
+	haveTTL := false
+
+	for i := 0; i < ancount; i++ {
+		rtype, ttl, rdata, _, next, err := readRR(msg, off)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		off = next
+
+		if rtype != dnsTypeTXT {
+			continue
+		}
+
+		for p := 0; p < len(rdata); {
+			l := int(rdata[p])
+			if p+1+l > len(rdata) {
+				break
+			}
+			values = append(values, string(rdata[p+1:p+1+l]))
+			p += 1 + l
+		}
+
+		if !haveTTL || ttl < minTTL {
+			minTTL = ttl
+			haveTTL = true
+		}
+	}
+
+	if len(values) > 0 {
+		return values, time.Duration(minTTL) * time.Second, false, nil
+	}
+
+	if soaTTL, ok := findSOAMinimum(msg, off, nscount); ok {
+		return nil, soaTTL, true, nil
+	}
+
+	return nil, 0, rcode != 0, nil
+}
+
+// decodeHeader validates the transaction id and returns the offset of
+// the first answer record plus the header's ANCOUNT/NSCOUNT/RCODE.
+func decodeHeader(msg []byte, id uint16) (off, ancount, nscount int, rcode byte, err error) {
+
+	if len(msg) < 12 {
+		return 0, 0, 0, 0, errDNSShortMessage
+	}
+
+	gotID := binary.BigEndian.Uint16(msg[0:])
+	if gotID != id {
+		return 0, 0, 0, 0, fmt.Errorf("resolver: dns id mismatch: got %d want %d", gotID, id)
+	}
+
+	rcode = msg[3] & 0x0f
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount = int(binary.BigEndian.Uint16(msg[6:]))
+	nscount = int(binary.BigEndian.Uint16(msg[8:]))
+
+	off = 12
+	for i := 0; i < qdcount; i++ {
+		off, err = skipName(msg, off)
+		if err != nil {
+			return 0, 0, 0, 0, err
+		}
+		off += 4 // QTYPE + QCLASS
+	}
+
+	return off, ancount, nscount, rcode, nil
+}
+
+// maxCNAMEHops bounds explicit CNAME chasing so a referral loop can't
+// hang a lookup.
+const maxCNAMEHops = 4
+
+// resolveWithCNAME calls query for host/qtype and, if the answer was a
+// bare CNAME with no matching address, follows it (up to
+// maxCNAMEHops times) until it finds addresses or another negative or
+// CNAME-less answer.
+func resolveWithCNAME(host string, qtype uint16, query func(name string, qtype uint16) (answer, error)) (answer, error) {
+
+	name := host
+
+	for hop := 0; hop < maxCNAMEHops; hop++ {
+		a, err := query(name, qtype)
+		if err != nil {
+			return answer{}, err
+		}
+		if len(a.addrs) > 0 || a.cname == "" {
+			return a, nil
+		}
+		name = a.cname
+	}
+
+	return answer{}, fmt.Errorf("resolver: too many CNAME hops resolving %s", host)
+}
+
+// mergeAnswers combines the A and AAAA answers for a single LookupHost
+// call into the Result the Resolver interface returns.
+func mergeAnswers(a, aaaa answer) Result {
+
+	var res Result
+	res.Addrs = append(res.Addrs, a.addrs...)
+	res.Addrs = append(res.Addrs, aaaa.addrs...)
+
+	for _, ans := range []answer{a, aaaa} {
+		if len(ans.addrs) == 0 {
+			continue
+		}
+		if res.TTL == 0 || ans.ttl < res.TTL {
+			res.TTL = ans.ttl
+		}
+	}
+
+	if len(res.Addrs) == 0 {
+		res.Negative = a.negative && aaaa.negative
+		res.NXDomain = res.Negative && (a.nxdomain || aaaa.nxdomain)
+		if a.negative && a.ttl > 0 {
+			res.TTL = a.ttl
+		}
+		if aaaa.negative && aaaa.ttl > 0 && (res.TTL == 0 || aaaa.ttl < res.TTL) {
+			res.TTL = aaaa.ttl
+		}
+	}
+
+	return res
+}
+
+// readRR reads one resource record starting at off and returns its
+// type, TTL, RDATA, the absolute offset RDATA starts at (names inside
+// RDATA, e.g. an SRV target, may use compression pointers relative to
+// the whole message), and the offset immediately following the record.
+func readRR(msg []byte, off int) (rtype uint16, ttl uint32, rdata []byte, rdataOff, next int, err error) {
+
+	off, err = skipName(msg, off)
+	if err != nil {
+		return 0, 0, nil, 0, 0, err
+	}
+
+	if off+10 > len(msg) {
+		return 0, 0, nil, 0, 0, errDNSShortMessage
+	}
+
+	rtype = binary.BigEndian.Uint16(msg[off:])
+	ttl = binary.BigEndian.Uint32(msg[off+4:])
+	rdlength := int(binary.BigEndian.Uint16(msg[off+8:]))
+	off += 10
+	rdataOff = off
+
+	if off+rdlength > len(msg) {
+		return 0, 0, nil, 0, 0, errDNSShortMessage
+	}
+	rdata = msg[off : off+rdlength]
+	off += rdlength
+
+	return rtype, ttl, rdata, rdataOff, off, nil
+}
+
+// decodeName reads a (possibly compressed) DNS name starting at off and
+// returns it in dotted form, plus the offset immediately after it (not
+// following any compression pointer).
+func decodeName(msg []byte, off int) (string, int, error) {
+
+	var labels []string
+	firstNext := -1
+	cur := off
+
+	for jumps := 0; ; jumps++ {
+		if jumps > 128 {
+			return "", 0, errors.New("resolver: dns name compression loop")
+		}
+		if cur >= len(msg) {
+			return "", 0, errDNSShortMessage
+		}
+
+		l := int(msg[cur])
+
+		if l == 0 {
+			if firstNext == -1 {
+				firstNext = cur + 1
+			}
+			break
+		}
+
+		if l&0xc0 == 0xc0 {
+			if cur+1 >= len(msg) {
+				return "", 0, errDNSShortMessage
+			}
+			if firstNext == -1 {
+				firstNext = cur + 2
+			}
+			cur = int(msg[cur]&0x3f)<<8 | int(msg[cur+1])
+			continue
+		}
+
+		if cur+1+l > len(msg) {
+			return "", 0, errDNSShortMessage
+		}
+		labels = append(labels, string(msg[cur+1:cur+1+l]))
+		cur += 1 + l
+	}
+
+	return strings.Join(labels, "."), firstNext, nil
+}
+
+// soaMinimum extracts the MINIMUM field (last 4 bytes) of a SOA RDATA.
+func soaMinimum(rdata []byte) (uint32, bool) {
+	if len(rdata) < 4 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(rdata[len(rdata)-4:]), true
+}
+
+func formatIPv6(b []byte) string {
+	parts := make([]string, 8)
+	for i := 0; i < 8; i++ {
+		parts[i] = fmt.Sprintf("%x", binary.BigEndian.Uint16(b[i*2:]))
+	}
+	return strings.Join(parts, ":")
+}
+
+// skipName advances past a (possibly compressed) DNS name starting at
+// off and returns the offset immediately after it.
+func skipName(msg []byte, off int) (int, error) {
+
+	for {
+		if off >= len(msg) {
+			return 0, errDNSShortMessage
+		}
+
+		l := int(msg[off])
+
+		if l == 0 {
+			return off + 1, nil
+		}
+
+		if l&0xc0 == 0xc0 {
+			// compression pointer, 2 bytes total
+			if off+1 >= len(msg) {
+				return 0, errDNSShortMessage
+			}
+			return off + 2, nil
+		}
+
+		off += 1 + l
+	}
+}
@@ -0,0 +1,163 @@
+package resolver
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// buildResponse assembles a minimal DNS response message: a single
+// question for qname/qtype, followed by the given pre-encoded answer
+// and authority records (already including their own name/type/class/
+// ttl/rdlength/rdata bytes).
+func buildResponse(id uint16, rcode byte, qname string, qtype uint16, ancount, nscount int, answers, authority []byte) []byte {
+
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	msg[3] = rcode & 0x0f
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+	binary.BigEndian.PutUint16(msg[6:], uint16(ancount))
+	binary.BigEndian.PutUint16(msg[8:], uint16(nscount))
+
+	msg = append(msg, encodeName(qname)...)
+	qt := make([]byte, 4)
+	binary.BigEndian.PutUint16(qt[0:], qtype)
+	binary.BigEndian.PutUint16(qt[2:], dnsClassIN)
+	msg = append(msg, qt...)
+
+	msg = append(msg, answers...)
+	msg = append(msg, authority...)
+
+	return msg
+}
+
+// encodeARecord builds an answer RR for name (a compression pointer to
+// nameOff when pointer is true, otherwise name is encoded inline) with
+// the given ttl and IPv4 address.
+func encodeARecord(name string, pointer bool, nameOff uint16, ttl uint32, ip [4]byte) []byte {
+
+	var rr []byte
+	if pointer {
+		rr = append(rr, 0xc0|byte(nameOff>>8), byte(nameOff))
+	} else {
+		rr = append(rr, encodeName(name)...)
+	}
+
+	typeClassTTL := make([]byte, 8)
+	binary.BigEndian.PutUint16(typeClassTTL[0:], dnsTypeA)
+	binary.BigEndian.PutUint16(typeClassTTL[2:], dnsClassIN)
+	binary.BigEndian.PutUint32(typeClassTTL[4:], ttl)
+	rr = append(rr, typeClassTTL...)
+
+	rdlength := make([]byte, 2)
+	binary.BigEndian.PutUint16(rdlength, 4)
+	rr = append(rr, rdlength...)
+	rr = append(rr, ip[:]...)
+
+	return rr
+}
+
+func TestEncodeDecodeNamePlain(t *testing.T) {
+
+	encoded := encodeName("example.com")
+
+	name, next, err := decodeName(encoded, 0)
+	if err != nil {
+		t.Fatalf("decodeName: %v", err)
+	}
+	if name != "example.com" {
+		t.Fatalf("got name %q, want %q", name, "example.com")
+	}
+	if next != len(encoded) {
+		t.Fatalf("got next %d, want %d", next, len(encoded))
+	}
+}
+
+func TestDecodeAnswerARecord(t *testing.T) {
+
+	const qname = "example.com"
+
+	msg := buildResponse(42, 0, qname, dnsTypeA, 1, 0,
+		encodeARecord(qname, false, 0, 300, [4]byte{93, 184, 216, 34}),
+		nil,
+	)
+
+	a, err := decodeAnswer(msg, 42, dnsTypeA)
+	if err != nil {
+		t.Fatalf("decodeAnswer: %v", err)
+	}
+	if len(a.addrs) != 1 || a.addrs[0] != "93.184.216.34" {
+		t.Fatalf("got addrs %v, want [93.184.216.34]", a.addrs)
+	}
+	if a.ttl != 300*time.Second {
+		t.Fatalf("got ttl %v, want 300s", a.ttl)
+	}
+}
+
+func TestDecodeAnswerCompressedName(t *testing.T) {
+
+	const qname = "example.com"
+
+	// The question name starts right after the 12-byte header.
+	msg := buildResponse(7, 0, qname, dnsTypeA, 1, 0,
+		encodeARecord(qname, true, 12, 60, [4]byte{1, 2, 3, 4}),
+		nil,
+	)
+
+	a, err := decodeAnswer(msg, 7, dnsTypeA)
+	if err != nil {
+		t.Fatalf("decodeAnswer: %v", err)
+	}
+	if len(a.addrs) != 1 || a.addrs[0] != "1.2.3.4" {
+		t.Fatalf("got addrs %v, want [1.2.3.4] (compression pointer not followed?)", a.addrs)
+	}
+}
+
+func TestDecodeAnswerNXDomainIsNXDomain(t *testing.T) {
+
+	msg := buildResponse(1, dnsRcodeNXDomain, "missing.example.com", dnsTypeA, 0, 0, nil, nil)
+
+	a, err := decodeAnswer(msg, 1, dnsTypeA)
+	if err != nil {
+		t.Fatalf("decodeAnswer: %v", err)
+	}
+	if !a.negative || !a.nxdomain {
+		t.Fatalf("got negative=%v nxdomain=%v, want both true for NXDOMAIN", a.negative, a.nxdomain)
+	}
+}
+
+func TestDecodeAnswerServfailIsNotNXDomain(t *testing.T) {
+
+	const rcodeServfail = 2
+
+	msg := buildResponse(2, rcodeServfail, "example.com", dnsTypeA, 0, 0, nil, nil)
+
+	a, err := decodeAnswer(msg, 2, dnsTypeA)
+	if err != nil {
+		t.Fatalf("decodeAnswer: %v", err)
+	}
+	if !a.negative {
+		t.Fatalf("got negative=false, want true for SERVFAIL")
+	}
+	if a.nxdomain {
+		t.Fatalf("got nxdomain=true, want false for SERVFAIL (not a confirmed NXDOMAIN)")
+	}
+}
+
+func TestMergeAnswersOnlyNXDomainWhenBothConfirmed(t *testing.T) {
+
+	nxA := answer{negative: true, nxdomain: true}
+	nxAAAA := answer{negative: true, nxdomain: true}
+	servfailA := answer{negative: true}
+	servfailAAAA := answer{negative: true}
+
+	res := mergeAnswers(nxA, nxAAAA)
+	if !res.Negative || !res.NXDomain {
+		t.Fatalf("got Negative=%v NXDomain=%v, want both true when both sides are NXDOMAIN", res.Negative, res.NXDomain)
+	}
+
+	res = mergeAnswers(servfailA, servfailAAAA)
+	if !res.Negative || res.NXDomain {
+		t.Fatalf("got Negative=%v NXDomain=%v, want Negative=true NXDomain=false for SERVFAIL", res.Negative, res.NXDomain)
+	}
+}
@@ -0,0 +1,73 @@
+package resolver
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hostSnapshot is a structured, per-host view of a resolver's cached
+// state, used by DumpJSON and DumpYAML.
+type hostSnapshot struct {
+	Host           string        `json:"host" yaml:"host"`
+	V4             []string      `json:"v4" yaml:"v4"`
+	V6             []string      `json:"v6" yaml:"v6"`
+	LastResolvedAt time.Time     `json:"lastResolvedAt" yaml:"lastResolvedAt"`
+	LastError      string        `json:"lastError,omitempty" yaml:"lastError,omitempty"`
+	TTLRemaining   time.Duration `json:"ttlRemaining" yaml:"ttlRemaining"`
+	Idx            int           `json:"idx" yaml:"idx"`
+}
+
+// snapshot builds the sorted-by-host hostSnapshot list shared by
+// DumpJSON and DumpYAML.
+func (s *svc) snapshot() []hostSnapshot {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.hosts))
+	for host := range s.hosts {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+
+	out := make([]hostSnapshot, 0, len(names))
+	for _, name := range names {
+		r := s.hosts[name]
+
+		snap := hostSnapshot{
+			Host:           name,
+			V4:             append([]string{}, r.ip4...),
+			V6:             append([]string{}, r.ip6...),
+			LastResolvedAt: r.lastResolvedAt,
+			Idx:            r.lastIdx,
+		}
+		if r.lastErr != nil {
+			snap.LastError = r.lastErr.Error()
+		}
+		if !r.lastResolvedAt.IsZero() {
+			if remaining := r.ttl - time.Since(r.lastResolvedAt); remaining > 0 {
+				snap.TTLRemaining = remaining
+			}
+		}
+
+		out = append(out, snap)
+	}
+
+	return out
+}
+
+// DumpJSON writes a structured JSON snapshot of every tracked host's
+// resolved addresses and cache state.
+func (s *svc) DumpJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s.snapshot())
+}
+
+// DumpYAML writes a structured YAML snapshot of every tracked host's
+// resolved addresses and cache state.
+func (s *svc) DumpYAML(w io.Writer) error {
+	return yaml.NewEncoder(w).Encode(s.snapshot())
+}
@@ -0,0 +1,104 @@
+package resolver
+
+import "sync"
+
+// EventType distinguishes the kind of change an Event reports.
+type EventType int
+
+const (
+	// EventIPsChanged is published when a host's resolved A/AAAA
+	// addresses change.
+	EventIPsChanged EventType = iota
+	// EventSRVChanged is published when an SRV name's targets change.
+	EventSRVChanged
+)
+
+// Event describes a change to a subscribed name's resolved topology.
+type Event struct {
+	Type       EventType
+	Name       string
+	AddedIPs   []string
+	RemovedIPs []string
+	SRV        []SRVTarget
+}
+
+// CancelFunc stops a Subscribe subscription and releases its channel.
+type CancelFunc func()
+
+// Subscribe returns a channel that receives an Event every time name's
+// resolved IPs (for a host added via AddHost/AddHostWithOptions) or SRV
+// targets (for a name added via AddSRV) change, plus a CancelFunc to
+// stop receiving and release the channel. The channel is buffered;
+// a subscriber that falls behind misses events rather than blocking
+// resolution.
+func (s *svc) Subscribe(name string) (<-chan Event, CancelFunc) {
+
+	ch := make(chan Event, 16)
+
+	s.subsMu.Lock()
+	if s.subs == nil {
+		s.subs = make(map[string][]chan Event)
+	}
+	s.subs[name] = append(s.subs[name], ch)
+	s.subsMu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			s.subsMu.Lock()
+			defer s.subsMu.Unlock()
+			chans := s.subs[name]
+			for i, c := range chans {
+				if c == ch {
+					s.subs[name] = append(chans[:i], chans[i+1:]...)
+					break
+				}
+			}
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+func (s *svc) publish(name string, ev Event) {
+
+	s.subsMu.Lock()
+	chans := append([]chan Event{}, s.subs[name]...)
+	s.subsMu.Unlock()
+
+	for _, ch := range chans {
+		select {
+		case ch <- ev:
+		default:
+			s.log.Error().Println(s.tag, "dropped event for:", name, "- subscriber not keeping up")
+		}
+	}
+}
+
+// diffStrings returns the entries of next missing from prev (added) and
+// the entries of prev missing from next (removed).
+func diffStrings(prev, next []string) (added, removed []string) {
+
+	prevSet := make(map[string]struct{}, len(prev))
+	for _, v := range prev {
+		prevSet[v] = struct{}{}
+	}
+	nextSet := make(map[string]struct{}, len(next))
+	for _, v := range next {
+		nextSet[v] = struct{}{}
+	}
+
+	for _, v := range next {
+		if _, ok := prevSet[v]; !ok {
+			added = append(added, v)
+		}
+	}
+	for _, v := range prev {
+		if _, ok := nextSet[v]; !ok {
+			removed = append(removed, v)
+		}
+	}
+
+	return added, removed
+}
@@ -0,0 +1,103 @@
+// Package grpcresolver adapts an ExtendedResolver into a
+// google.golang.org/grpc/resolver.Builder, so this module's host
+// tracking (TTL-driven re-resolution, hosts-file merging, topology
+// events) can drive gRPC dial targets directly.
+package grpcresolver
+
+import (
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/resolver"
+
+	tdxresolver "github.com/tdx/resolver"
+)
+
+// Scheme is the URI scheme this package registers its Builder under,
+// e.g. grpc.Dial("tdx:///example.com:8080", ...).
+const Scheme = "tdx"
+
+type resolverBuilder struct {
+	svc tdxresolver.ExtendedResolver
+}
+
+// NewBuilder returns a resolver.Builder backed by svc. Register it with
+// resolver.Register before dialing a "tdx:///" target.
+func NewBuilder(svc tdxresolver.ExtendedResolver) resolver.Builder {
+	return &resolverBuilder{svc: svc}
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+func (b *resolverBuilder) Build(
+	target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions,
+) (resolver.Resolver, error) {
+
+	host, port, err := net.SplitHostPort(target.Endpoint())
+	if err != nil {
+		return nil, fmt.Errorf("grpcresolver: target %q must be host:port: %w", target.Endpoint(), err)
+	}
+
+	r := &gRPCResolver{svc: b.svc, cc: cc, host: host, port: port}
+	r.start()
+
+	return r, nil
+}
+
+// gRPCResolver implements google.golang.org/grpc/resolver.Resolver,
+// pushing ClientConn state updates whenever host's resolved IPs change.
+type gRPCResolver struct {
+	svc    tdxresolver.ExtendedResolver
+	cc     resolver.ClientConn
+	host   string
+	port   string
+	cancel tdxresolver.CancelFunc
+}
+
+func (r *gRPCResolver) start() {
+
+	// Subscribe before AddHost starts polling, so the initial resolution's
+	// EventIPsChanged lands in the (buffered) events channel even if this
+	// goroutine hasn't started consuming it yet, instead of being
+	// silently dropped.
+	events, cancel := r.svc.Subscribe(r.host)
+	r.cancel = cancel
+
+	r.svc.AddHost(r.host)
+
+	r.pushState()
+
+	go func() {
+		for range events {
+			r.pushState()
+		}
+	}()
+}
+
+func (r *gRPCResolver) pushState() {
+
+	ip4, ip6 := r.svc.GetIPsStr(r.host)
+
+	addrs := make([]resolver.Address, 0, len(ip4)+len(ip6))
+	for _, ip := range ip4 {
+		addrs = append(addrs, resolver.Address{Addr: net.JoinHostPort(ip, r.port)})
+	}
+	for _, ip := range ip6 {
+		addrs = append(addrs, resolver.Address{Addr: net.JoinHostPort(ip, r.port)})
+	}
+
+	_ = r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+// ResolveNow is a no-op: addresses are already kept current by the
+// underlying resolver's TTL-driven polling.
+func (r *gRPCResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+// Close stops the Subscribe subscription backing this resolver.
+func (r *gRPCResolver) Close() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
@@ -0,0 +1,190 @@
+// Package hosts parses and watches an /etc/hosts style file, exposing
+// its entries as a first-class resolution source that callers can merge
+// ahead of network DNS.
+package hosts
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// File is a parsed, optionally-watched hosts file. The zero value is
+// not usable; construct one with New.
+type File struct {
+	mu      sync.RWMutex
+	path    string
+	ipv4    map[string][]net.IP
+	ipv6    map[string][]net.IP
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// New parses the hosts file at path. Use DefaultPath to locate the
+// platform's standard hosts file.
+func New(path string) (*File, error) {
+
+	f := &File{path: path}
+
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Watch starts watching the hosts file for changes via fsnotify and
+// reloads entries whenever it is written. Call Close to stop watching.
+func (f *File) Watch() error {
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	if err := w.Add(f.path); err != nil {
+		w.Close()
+		return err
+	}
+
+	f.watcher = w
+	f.done = make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+					_ = f.reload()
+				}
+			case _, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+			case <-f.done:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops the fsnotify watcher, if one was started via Watch.
+func (f *File) Close() error {
+
+	if f.watcher == nil {
+		return nil
+	}
+
+	close(f.done)
+
+	return f.watcher.Close()
+}
+
+// Lookup returns every IPv4 and IPv6 address the hosts file lists for
+// host, matched case-insensitively against canonical names and aliases.
+func (f *File) Lookup(host string) (ipv4, ipv6 []net.IP) {
+
+	key := strings.ToLower(host)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.ipv4[key], f.ipv6[key]
+}
+
+// Hosts returns every hostname (and alias) the file currently binds to
+// at least one address, e.g. for a service that wants to bind-listen on
+// every address configured for its own hostname.
+func (f *File) Hosts() []string {
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	seen := make(map[string]struct{}, len(f.ipv4)+len(f.ipv6))
+	for name := range f.ipv4 {
+		seen[name] = struct{}{}
+	}
+	for name := range f.ipv6 {
+		seen[name] = struct{}{}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (f *File) reload() error {
+
+	ipv4, ipv6, err := parseFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.ipv4 = ipv4
+	f.ipv6 = ipv6
+	f.mu.Unlock()
+
+	return nil
+}
+
+// parseFile reads a hosts file, returning its entries keyed by
+// lower-cased hostname/alias.
+func parseFile(path string) (ipv4, ipv6 map[string][]net.IP, err error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	ipv4 = make(map[string][]net.IP)
+	ipv6 = make(map[string][]net.IP)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+
+		line := scanner.Text()
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+
+		m := ipv4
+		if ip.To4() == nil {
+			m = ipv6
+		}
+
+		for _, name := range fields[1:] {
+			key := strings.ToLower(name)
+			m[key] = append(m[key], ip)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return ipv4, ipv6, nil
+}
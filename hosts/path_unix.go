@@ -0,0 +1,19 @@
+//go:build !windows
+
+package hosts
+
+import "os"
+
+// DefaultPath returns the OS hosts file path: the file named by
+// RESOLVER_HOSTS_FILE if set, otherwise /etc/hosts. RESOLVER_HOSTS_FILE
+// is this package's own override (an /etc/hosts-format path), not the
+// BSD/glibc HOSTALIASES mechanism - that points at a differently
+// formatted "alias canonical-name" file and is not implemented here.
+func DefaultPath() string {
+
+	if p := os.Getenv("RESOLVER_HOSTS_FILE"); p != "" {
+		return p
+	}
+
+	return "/etc/hosts"
+}
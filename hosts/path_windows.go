@@ -0,0 +1,25 @@
+//go:build windows
+
+package hosts
+
+import "os"
+
+// DefaultPath returns the OS hosts file path: the file named by
+// RESOLVER_HOSTS_FILE if set, otherwise the standard Windows hosts file
+// under %SystemRoot%. RESOLVER_HOSTS_FILE is this package's own override
+// (an /etc/hosts-format path), not the BSD/glibc HOSTALIASES mechanism -
+// that points at a differently formatted "alias canonical-name" file and
+// is not implemented here.
+func DefaultPath() string {
+
+	if p := os.Getenv("RESOLVER_HOSTS_FILE"); p != "" {
+		return p
+	}
+
+	root := os.Getenv("SystemRoot")
+	if root == "" {
+		root = `C:\Windows`
+	}
+
+	return root + `\System32\drivers\etc\hosts`
+}
@@ -0,0 +1,64 @@
+package resolver
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ipCountDesc = prometheus.NewDesc(
+		"resolver_ips",
+		"Number of resolved addresses cached for a host, by family.",
+		[]string{"tag", "host", "family"}, nil,
+	)
+	resolveSuccessDesc = prometheus.NewDesc(
+		"resolver_resolve_success_total",
+		"Number of successful resolutions for a host.",
+		[]string{"tag", "host"}, nil,
+	)
+	resolveFailureDesc = prometheus.NewDesc(
+		"resolver_resolve_failure_total",
+		"Number of failed resolutions for a host.",
+		[]string{"tag", "host"}, nil,
+	)
+)
+
+// metricsCollector adapts svc's internal per-host state into a
+// prometheus.Collector, combining point-in-time gauge/counter snapshots
+// with the persistent resolve-latency histogram recorded during polling.
+type metricsCollector struct {
+	s *svc
+}
+
+// Collector returns a prometheus.Collector exposing per-host IP counts,
+// resolution success/failure counters, and a resolve-latency histogram.
+// Register it with a prometheus.Registry to make this resolver
+// observable.
+func (s *svc) Collector() prometheus.Collector {
+	return &metricsCollector{s: s}
+}
+
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ipCountDesc
+	ch <- resolveSuccessDesc
+	ch <- resolveFailureDesc
+	c.s.latencyHist.Describe(ch)
+}
+
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+
+	c.s.mu.RLock()
+	defer c.s.mu.RUnlock()
+
+	for host, r := range c.s.hosts {
+		ch <- prometheus.MustNewConstMetric(
+			ipCountDesc, prometheus.GaugeValue, float64(len(r.ip4)), c.s.tag, host, "v4")
+		ch <- prometheus.MustNewConstMetric(
+			ipCountDesc, prometheus.GaugeValue, float64(len(r.ip6)), c.s.tag, host, "v6")
+		ch <- prometheus.MustNewConstMetric(
+			resolveSuccessDesc, prometheus.CounterValue, float64(r.okCount), c.s.tag, host)
+		ch <- prometheus.MustNewConstMetric(
+			resolveFailureDesc, prometheus.CounterValue, float64(r.errCount), c.s.tag, host)
+	}
+
+	c.s.latencyHist.Collect(ch)
+}
@@ -0,0 +1,99 @@
+package resolver
+
+import (
+	"time"
+
+	"github.com/tdx/resolver/hosts"
+)
+
+// Option configures svc-wide defaults at New time.
+type Option func(*svc)
+
+// WithDefaultResolver sets the Resolver backend used for hosts added
+// without an explicit per-host resolver. Defaults to NewSystemResolver.
+func WithDefaultResolver(r Resolver) Option {
+	return func(s *svc) {
+		s.resolver = r
+	}
+}
+
+// WithTTLFloor sets the minimum interval between re-resolves of a host,
+// regardless of how short its reported TTL is. Defaults to
+// defaultTTLFloor.
+func WithTTLFloor(d time.Duration) Option {
+	return func(s *svc) {
+		s.ttlFloor = d
+	}
+}
+
+// WithTTLCeiling sets the maximum interval between re-resolves of a
+// host, used when a backend reports no TTL or an implausibly long one.
+// Defaults to defaultTTLCeiling.
+func WithTTLCeiling(d time.Duration) Option {
+	return func(s *svc) {
+		s.ttlCeiling = d
+	}
+}
+
+// WithHostsFile merges f's entries into GetIPs/GetIPsStr/GetNextIP for
+// every host that does not opt out with WithBypassHostsFile. Construct
+// f with hosts.New(hosts.DefaultPath()) and call f.Watch() to pick up
+// edits live.
+func WithHostsFile(f *hosts.File) Option {
+	return func(s *svc) {
+		s.hostsFile = f
+	}
+}
+
+// hostConfig holds the resolved per-host options for a single AddHost
+// call, captured once and reused for every poll of that host.
+type hostConfig struct {
+	resolver    Resolver
+	ipv4Only    bool
+	ipv6Only    bool
+	bypassHosts bool
+	selector    Selector
+}
+
+// HostOption configures the resolution behavior of a single host added
+// via AddHostWithOptions.
+type HostOption func(*hostConfig)
+
+// WithHostResolver pins this host to a specific Resolver backend,
+// overriding the svc-wide default.
+func WithHostResolver(r Resolver) HostOption {
+	return func(c *hostConfig) {
+		c.resolver = r
+	}
+}
+
+// WithIPv4Only restricts resolution to A records for this host.
+func WithIPv4Only() HostOption {
+	return func(c *hostConfig) {
+		c.ipv4Only = true
+	}
+}
+
+// WithIPv6Only restricts resolution to AAAA records for this host.
+func WithIPv6Only() HostOption {
+	return func(c *hostConfig) {
+		c.ipv6Only = true
+	}
+}
+
+// WithBypassHostsFile skips any /etc/hosts style overrides for this
+// host, forcing resolution through the configured Resolver even when a
+// hosts-file entry would otherwise take precedence.
+func WithBypassHostsFile() HostOption {
+	return func(c *hostConfig) {
+		c.bypassHosts = true
+	}
+}
+
+// WithSelector picks the load-balancing strategy GetNextIP uses for
+// this host. Defaults to NewRoundRobinSelector.
+func WithSelector(sel Selector) HostOption {
+	return func(c *hostConfig) {
+		c.selector = sel
+	}
+}
@@ -7,40 +7,131 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	logApi "github.com/tdx/go/api/log"
 	resolverApi "github.com/tdx/go/api/resolver"
+
+	"github.com/tdx/resolver/hosts"
+)
+
+// defaultTTLFloor and defaultTTLCeiling bound the re-resolve interval
+// when a backend reports no usable TTL (e.g. systemResolver) or an
+// implausible one, keeping behavior close to the original fixed
+// 60-second poll while still honoring real TTLs in between.
+const (
+	defaultTTLFloor   = 5 * time.Second
+	defaultTTLCeiling = 5 * time.Minute
 )
 
 type ips struct {
-	idx  int
-	ip4  []string
-	ip6  []string
-	ipv4 []net.IP
-	ipv6 []net.IP
+	ip4            []string
+	ip6            []string
+	ipv4           []net.IP
+	ipv6           []net.IP
+	cfg            hostConfig
+	ttl            time.Duration
+	lastResolvedAt time.Time
+	lastErr        error
+	refresh        chan struct{}
+	keySel         Selector
+	lastIdx        int
+	okCount        uint64
+	errCount       uint64
 }
 
 type svc struct {
-	mu    sync.RWMutex
-	hosts map[string]*ips
-	tag   string
-	log   logApi.Logger
+	mu         sync.RWMutex
+	hosts      map[string]*ips
+	tag        string
+	log        logApi.Logger
+	resolver   Resolver
+	ttlFloor   time.Duration
+	ttlCeiling time.Duration
+	resolveOK  uint64
+	resolveErr uint64
+	hostsFile  *hosts.File
+	rttMu      sync.RWMutex
+	rtt        map[string]map[string]time.Duration
+	srvMu      sync.RWMutex
+	srv        map[string]*srvRecord
+	txtMu      sync.RWMutex
+	txt        map[string]*txtRecord
+	subsMu     sync.Mutex
+	subs       map[string][]chan Event
+
+	latencyHist *prometheus.HistogramVec
+}
+
+// CacheStats is a point-in-time snapshot of the resolver's cached
+// answers, for observability.
+type CacheStats struct {
+	Hosts           int
+	ResolveSuccess  uint64
+	ResolveFailures uint64
+}
+
+// ExtendedResolver is resolverApi.Resolver plus the per-host resolution
+// options and cache observability introduced alongside pluggable
+// Resolver backends.
+type ExtendedResolver interface {
+	resolverApi.Resolver
+	AddHostWithOptions(host string, opts ...HostOption)
+	CacheStats() CacheStats
+	EvictHost(host string) bool
+	GetIPForKey(host, key string) string
+	ReportRTT(host, ip string, d time.Duration)
+	AddSRV(service, proto, name string)
+	GetSRV(qname string) []SRVTarget
+	AddTXT(name string)
+	GetTXT(name string) []string
+	Subscribe(name string) (<-chan Event, CancelFunc)
+	DumpJSON(w io.Writer) error
+	DumpYAML(w io.Writer) error
+	Collector() prometheus.Collector
 }
 
 // New returns ResolverService instance
-func New(tag string, log logApi.Logger) resolverApi.Resolver {
+func New(tag string, log logApi.Logger, opts ...Option) ExtendedResolver {
 
 	s := &svc{
-		hosts: make(map[string]*ips),
-		tag:   tag,
-		log:   log,
+		hosts:      make(map[string]*ips),
+		tag:        tag,
+		log:        log,
+		resolver:   NewSystemResolver(),
+		ttlFloor:   defaultTTLFloor,
+		ttlCeiling: defaultTTLCeiling,
+		latencyHist: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "resolver",
+				Name:      "resolve_duration_seconds",
+				Help:      "Duration of host resolution attempts, in seconds.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"tag", "host"},
+		),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
 
 	return s
 }
 
 func (s *svc) AddHost(host string) {
+	s.AddHostWithOptions(host)
+}
+
+// AddHostWithOptions starts polling host for its A/AAAA addresses,
+// applying the given per-host options (resolver backend, address
+// family restriction, hosts-file bypass). Instead of a fixed interval,
+// each poll is scheduled from the resolved answer's TTL, clamped to
+// [ttlFloor, ttlCeiling].
+func (s *svc) AddHostWithOptions(host string, opts ...HostOption) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -49,26 +140,116 @@ func (s *svc) AddHost(host string) {
 		return
 	}
 
-	s.hosts[host] = &ips{}
+	cfg := hostConfig{resolver: s.resolver}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.selector == nil {
+		cfg.selector = NewRoundRobinSelector()
+	}
+
+	refresh := make(chan struct{}, 1)
+	s.hosts[host] = &ips{cfg: cfg, refresh: refresh, keySel: NewConsistentHashSelector()}
 
 	go func(p *svc) {
 		s.log.Info().Println(s.tag, "start resolver for:", host)
 		for {
-			ips, err := net.LookupHost(host)
-			if err == nil {
-				if !p.updateHostIPs(host, ips) {
+			start := time.Now()
+			res, err := cfg.resolver.LookupHost(host)
+			s.latencyHist.WithLabelValues(s.tag, host).Observe(time.Since(start).Seconds())
+
+			var sleep time.Duration
+			switch {
+			case err == nil && (!res.Negative || res.NXDomain):
+				atomic.AddUint64(&s.resolveOK, 1)
+				if !p.updateHostIPs(host, res) {
 					s.log.Error().Println(s.tag, "stop resolver for:", host)
 					return
 				}
-			} else {
+				sleep = clampTTL(res.TTL, s.ttlFloor, s.ttlCeiling)
+
+			case err == nil:
+				// Negative but not a confirmed NXDOMAIN (NODATA, SERVFAIL,
+				// ...): treat it like a transient failure and keep whatever
+				// addresses are already cached instead of wiping them.
+				atomic.AddUint64(&s.resolveErr, 1)
+				s.log.Error().Println(s.tag, "resolve", host, "negative, non-nxdomain, keeping cache")
+				if !p.recordFailure(host, fmt.Errorf("resolver: negative response for %s", host)) {
+					s.log.Error().Println(s.tag, "stop resolver for:", host)
+					return
+				}
+				sleep = clampTTL(res.TTL, s.ttlFloor, s.ttlCeiling)
+
+			default:
+				atomic.AddUint64(&s.resolveErr, 1)
 				s.log.Error().Println(s.tag, "resolve", host, "failed:", err)
+				if !p.recordFailure(host, err) {
+					s.log.Error().Println(s.tag, "stop resolver for:", host)
+					return
+				}
+				sleep = s.ttlFloor
 			}
 
-			time.Sleep(time.Duration(60 * time.Second))
+			timer := time.NewTimer(sleep)
+			select {
+			case <-timer.C:
+			case <-refresh:
+				timer.Stop()
+			}
 		}
 	}(s)
 }
 
+// clampTTL bounds ttl to [floor, ceiling], treating a non-positive ttl
+// (unknown, e.g. from a backend that cannot report one) as floor.
+func clampTTL(ttl, floor, ceiling time.Duration) time.Duration {
+	if ttl <= 0 {
+		return floor
+	}
+	if ttl < floor {
+		return floor
+	}
+	if ttl > ceiling {
+		return ceiling
+	}
+	return ttl
+}
+
+// CacheStats returns a snapshot of the resolver's cache state for
+// observability.
+func (s *svc) CacheStats() CacheStats {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return CacheStats{
+		Hosts:           len(s.hosts),
+		ResolveSuccess:  atomic.LoadUint64(&s.resolveOK),
+		ResolveFailures: atomic.LoadUint64(&s.resolveErr),
+	}
+}
+
+// EvictHost forces an immediate re-resolve of host on its next poll
+// cycle instead of waiting out its cached TTL. Returns false if host is
+// not tracked.
+func (s *svc) EvictHost(host string) bool {
+
+	s.mu.RLock()
+	r, ok := s.hosts[host]
+	s.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	select {
+	case r.refresh <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
 func (s *svc) DelHost(host string) {
 	s.mu.Lock()
 	delete(s.hosts, host)
@@ -77,10 +258,34 @@ func (s *svc) DelHost(host string) {
 
 func (s *svc) Stop() {
 	s.mu.Lock()
-	for host := range s.hosts {
+	for host, r := range s.hosts {
 		delete(s.hosts, host)
+		select {
+		case r.refresh <- struct{}{}:
+		default:
+		}
 	}
 	s.mu.Unlock()
+
+	s.srvMu.Lock()
+	for qname, r := range s.srv {
+		delete(s.srv, qname)
+		select {
+		case r.refresh <- struct{}{}:
+		default:
+		}
+	}
+	s.srvMu.Unlock()
+
+	s.txtMu.Lock()
+	for name, r := range s.txt {
+		delete(s.txt, name)
+		select {
+		case r.refresh <- struct{}{}:
+		default:
+		}
+	}
+	s.txtMu.Unlock()
 }
 
 func (s *svc) GetNextIP(host string) string {
@@ -90,50 +295,146 @@ func (s *svc) GetNextIP(host string) string {
 	return ip
 }
 
+// GetNextIPWithIdx selects one address from host's merged v4+v6 pool
+// using its configured Selector (round-robin by default).
 func (s *svc) GetNextIPWithIdx(host string) (string, int) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if r, ok := s.hosts[host]; ok {
+	r, ok := s.hosts[host]
+	if !ok {
+		return "", -1
+	}
 
-		itemsCount := len(r.ip4)
-		if itemsCount == 0 {
-			return "", -1
-		}
+	hostsIP4, hostsIP6 := s.hostsFileLookupStr(host, r)
+	pool := make([]string, 0, len(hostsIP4)+len(hostsIP6)+len(r.ip4)+len(r.ip6))
+	pool = append(pool, hostsIP4...)
+	pool = append(pool, hostsIP6...)
+	pool = append(pool, r.ip4...)
+	pool = append(pool, r.ip6...)
+
+	ip, idx := r.cfg.selector.Select(pool, SelectContext{RTT: s.rttFor(host)})
+	r.lastIdx = idx
+
+	return ip, idx
+}
 
-		r.idx = (r.idx + 1) % itemsCount
+// GetIPForKey deterministically selects one address from host's merged
+// pool for key, independent of the host's configured Selector.
+func (s *svc) GetIPForKey(host, key string) string {
+
+	s.mu.RLock()
+	r, ok := s.hosts[host]
+	s.mu.RUnlock()
 
-		return r.ip4[r.idx], r.idx
+	if !ok {
+		return ""
 	}
 
-	return "", -1
+	hostsIP4, hostsIP6 := s.hostsFileLookupStr(host, r)
+	pool := make([]string, 0, len(hostsIP4)+len(hostsIP6)+len(r.ip4)+len(r.ip6))
+	pool = append(pool, hostsIP4...)
+	pool = append(pool, hostsIP6...)
+	pool = append(pool, r.ip4...)
+	pool = append(pool, r.ip6...)
+
+	ip, _ := r.keySel.Select(pool, SelectContext{Key: key})
+
+	return ip
+}
+
+// ReportRTT records an observed round-trip time for host/ip, consumed
+// by a P2C latency-aware Selector (see NewP2CSelector).
+func (s *svc) ReportRTT(host, ip string, d time.Duration) {
+
+	s.rttMu.Lock()
+	defer s.rttMu.Unlock()
+
+	if s.rtt == nil {
+		s.rtt = make(map[string]map[string]time.Duration)
+	}
+	if s.rtt[host] == nil {
+		s.rtt[host] = make(map[string]time.Duration)
+	}
+	s.rtt[host][ip] = d
+}
+
+func (s *svc) rttFor(host string) func(ip string) (time.Duration, bool) {
+	return func(ip string) (time.Duration, bool) {
+		s.rttMu.RLock()
+		defer s.rttMu.RUnlock()
+		d, ok := s.rtt[host][ip]
+		return d, ok
+	}
 }
 
 func (s *svc) GetIPs(host string) ([]net.IP, []net.IP) {
 
 	s.mu.RLock()
-	hosts := s.hosts[host]
+	r := s.hosts[host]
 	s.mu.RUnlock()
 
-	if hosts == nil {
+	if r == nil {
 		return nil, nil
 	}
 
-	return hosts.ipv4, hosts.ipv6
+	ipv4, ipv6 := r.ipv4, r.ipv6
+	if s.hostsFileEnabled(r) {
+		hv4, hv6 := s.hostsFile.Lookup(host)
+		ipv4 = append(append([]net.IP{}, hv4...), ipv4...)
+		ipv6 = append(append([]net.IP{}, hv6...), ipv6...)
+	}
+
+	return ipv4, ipv6
 }
 
 func (s *svc) GetIPsStr(host string) ([]string, []string) {
 
 	s.mu.RLock()
-	hosts := s.hosts[host]
+	r := s.hosts[host]
 	s.mu.RUnlock()
 
-	if hosts == nil {
+	if r == nil {
+		return nil, nil
+	}
+
+	hostsIP4, hostsIP6 := s.hostsFileLookupStr(host, r)
+
+	ip4 := append(append([]string{}, hostsIP4...), r.ip4...)
+	ip6 := append(append([]string{}, hostsIP6...), r.ip6...)
+
+	return ip4, ip6
+}
+
+// hostsFileEnabled reports whether r's host should be merged with the
+// configured hosts file: one is configured and the host did not opt
+// out via WithBypassHostsFile.
+func (s *svc) hostsFileEnabled(r *ips) bool {
+	return s.hostsFile != nil && !r.cfg.bypassHosts
+}
+
+// hostsFileLookupStr is hostsFileEnabled's result as string addresses,
+// ready to prepend to a host's resolved pool.
+func (s *svc) hostsFileLookupStr(host string, r *ips) (ip4, ip6 []string) {
+
+	if !s.hostsFileEnabled(r) {
 		return nil, nil
 	}
 
-	return hosts.ip4, hosts.ip6
+	hv4, hv6 := s.hostsFile.Lookup(host)
+
+	ip4 = make([]string, 0, len(hv4))
+	for _, ip := range hv4 {
+		ip4 = append(ip4, ip.String())
+	}
+
+	ip6 = make([]string, 0, len(hv6))
+	for _, ip := range hv6 {
+		ip6 = append(ip6, ip.String())
+	}
+
+	return ip4, ip6
 }
 
 func (s *svc) Dump(w io.Writer) {
@@ -176,8 +477,9 @@ func (s *svc) DumpPrefix(w io.Writer, prefix string) {
 	}
 }
 
-//
-func (s *svc) updateHostIPs(host string, sip []string) bool {
+// updateHostIPs applies a freshly resolved Result to host's cached
+// entry, filtering by the per-host address family options.
+func (s *svc) updateHostIPs(host string, res Result) bool {
 
 	s.mu.RLock()
 	r, ok := s.hosts[host]
@@ -194,7 +496,7 @@ func (s *svc) updateHostIPs(host string, sip []string) bool {
 		ipv6  []net.IP
 	)
 
-	for _, ip := range sip {
+	for _, ip := range res.Addrs {
 
 		ipp := net.ParseIP(ip)
 		if ipp == nil {
@@ -202,9 +504,15 @@ func (s *svc) updateHostIPs(host string, sip []string) bool {
 		}
 
 		if strings.Contains(ip, ":") {
+			if r.cfg.ipv4Only {
+				continue
+			}
 			ipv6 = append(ipv6, ipp)
 			ipsv6 = append(ipsv6, ip)
 		} else {
+			if r.cfg.ipv6Only {
+				continue
+			}
 			ipv4 = append(ipv4, ipp)
 			ipsv4 = append(ipsv4, ip)
 		}
@@ -212,17 +520,46 @@ func (s *svc) updateHostIPs(host string, sip []string) bool {
 	sort.Strings(ipsv4)
 	sort.Strings(ipsv6)
 
+	s.mu.Lock()
+	prev := append(append([]string{}, r.ip4...), r.ip6...)
+
 	r.ip4 = ipsv4
 	r.ip6 = ipsv6
 	r.ipv4 = ipv4
 	r.ipv6 = ipv6
+	r.ttl = res.TTL
+	r.lastResolvedAt = time.Now()
+	r.lastErr = nil
+	r.okCount++
+	s.mu.Unlock()
+
+	s.log.Debug().Println(s.tag,
+		"host:", host, "ips4:", ipsv4, "ips6:", ipsv6, "ttl:", res.TTL)
 
-	if r.idx > len(ipsv4)-1 {
-		r.idx = 0
+	next := append(append([]string{}, ipsv4...), ipsv6...)
+	if added, removed := diffStrings(prev, next); len(added) > 0 || len(removed) > 0 {
+		s.publish(host, Event{Type: EventIPsChanged, Name: host, AddedIPs: added, RemovedIPs: removed})
 	}
 
-	s.log.Debug().Println(s.tag, "idx:", r.idx,
-		"host:", host, "ips4:", ipsv4, "ips6:", ipsv6)
+	return true
+}
+
+// recordFailure notes a failed resolution attempt against host's cached
+// entry without touching its (still valid, stale) addresses.
+func (s *svc) recordFailure(host string, err error) bool {
+
+	s.mu.RLock()
+	r, ok := s.hosts[host]
+	s.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	s.mu.Lock()
+	r.lastErr = err
+	r.errCount++
+	s.mu.Unlock()
 
 	return true
 }
@@ -0,0 +1,119 @@
+package resolver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	logApi "github.com/tdx/go/api/log"
+)
+
+type nopStdLogger struct{}
+
+func (nopStdLogger) Print(...interface{})          {}
+func (nopStdLogger) Printf(string, ...interface{}) {}
+func (nopStdLogger) Println(...interface{})        {}
+func (nopStdLogger) Fatal(...interface{})          {}
+func (nopStdLogger) Fatalf(string, ...interface{}) {}
+func (nopStdLogger) Fatalln(...interface{})        {}
+
+type nopLogger struct{}
+
+func (nopLogger) Error() logApi.StdLogger { return nopStdLogger{} }
+func (nopLogger) Warn() logApi.StdLogger  { return nopStdLogger{} }
+func (nopLogger) Info() logApi.StdLogger  { return nopStdLogger{} }
+func (nopLogger) Debug() logApi.StdLogger { return nopStdLogger{} }
+func (nopLogger) SetLevel(logApi.Level)   {}
+func (nopLogger) GetLevel() logApi.Level  { return logApi.InfoLevel }
+
+// scriptedResolver replays results in order, repeating the last entry
+// once exhausted.
+type scriptedResolver struct {
+	mu      sync.Mutex
+	results []Result
+	i       int
+}
+
+func (r *scriptedResolver) LookupHost(string) (Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.i
+	if idx >= len(r.results) {
+		idx = len(r.results) - 1
+	} else {
+		r.i++
+	}
+
+	return r.results[idx], nil
+}
+
+// waitFor polls cond every millisecond until it returns true or the
+// deadline passes, failing the test in the latter case.
+func waitFor(t *testing.T, deadline time.Duration, cond func() bool) {
+	t.Helper()
+
+	end := time.Now().Add(deadline)
+	for time.Now().Before(end) {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("condition not met within %v", deadline)
+}
+
+func TestNegativeNonNXDomainKeepsStaleCache(t *testing.T) {
+
+	const host = "example.com"
+
+	fr := &scriptedResolver{
+		results: []Result{
+			{Addrs: []string{"10.0.0.1"}, TTL: time.Hour},
+			{Negative: true}, // SERVFAIL/NODATA-style, not a confirmed NXDOMAIN
+		},
+	}
+
+	s := New("test", nopLogger{}, WithTTLFloor(time.Millisecond), WithTTLCeiling(5*time.Millisecond)).(*svc)
+	defer s.Stop()
+
+	s.AddHostWithOptions(host, WithHostResolver(fr))
+
+	waitFor(t, 2*time.Second, func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.hosts[host] != nil && s.hosts[host].errCount > 0
+	})
+
+	s.mu.RLock()
+	ip4 := append([]string{}, s.hosts[host].ip4...)
+	s.mu.RUnlock()
+
+	if len(ip4) != 1 || ip4[0] != "10.0.0.1" {
+		t.Fatalf("negative, non-nxdomain response cleared the cache: got ip4=%v, want [10.0.0.1] preserved", ip4)
+	}
+}
+
+func TestNXDomainClearsCache(t *testing.T) {
+
+	const host = "example.com"
+
+	fr := &scriptedResolver{
+		results: []Result{
+			{Addrs: []string{"10.0.0.1"}, TTL: time.Hour},
+			{Negative: true, NXDomain: true},
+		},
+	}
+
+	s := New("test", nopLogger{}, WithTTLFloor(time.Millisecond), WithTTLCeiling(5*time.Millisecond)).(*svc)
+	defer s.Stop()
+
+	s.AddHostWithOptions(host, WithHostResolver(fr))
+
+	waitFor(t, 2*time.Second, func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.hosts[host] != nil && len(s.hosts[host].ip4) == 0 && s.hosts[host].okCount >= 2
+	})
+}
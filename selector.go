@@ -0,0 +1,240 @@
+package resolver
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SelectContext carries the extra information a Selector may use beyond
+// the address pool itself: a caller-supplied key for consistent hashing
+// and a lookup for the latest observed RTT per address.
+type SelectContext struct {
+	Key string
+	RTT func(ip string) (time.Duration, bool)
+}
+
+// Selector picks one address from pool, the host's merged, de-duplicated
+// v4+v6 address set. Implementations may keep their own state (e.g. a
+// round-robin cursor); a Selector is used for exactly one host.
+type Selector interface {
+	Select(pool []string, ctx SelectContext) (ip string, idx int)
+}
+
+// roundRobinSelector is the original GetNextIP behavior: plain
+// round-robin over the pool.
+type roundRobinSelector struct {
+	idx int
+}
+
+// NewRoundRobinSelector returns the default Selector: round-robin over
+// the address pool.
+func NewRoundRobinSelector() Selector {
+	return &roundRobinSelector{idx: -1}
+}
+
+func (s *roundRobinSelector) Select(pool []string, _ SelectContext) (string, int) {
+	if len(pool) == 0 {
+		return "", -1
+	}
+	s.idx = (s.idx + 1) % len(pool)
+	return pool[s.idx], s.idx
+}
+
+// randomSelector picks a uniformly random address from the pool on
+// every call.
+type randomSelector struct{}
+
+// NewRandomSelector returns a Selector that picks uniformly at random.
+func NewRandomSelector() Selector {
+	return randomSelector{}
+}
+
+func (randomSelector) Select(pool []string, _ SelectContext) (string, int) {
+	if len(pool) == 0 {
+		return "", -1
+	}
+	idx := rand.Intn(len(pool))
+	return pool[idx], idx
+}
+
+// weightedSelector does weighted-random selection using per-address
+// weights (e.g. SRV priority/weight). Addresses with no configured
+// weight get weight 1.
+type weightedSelector struct {
+	weights map[string]int
+}
+
+// NewWeightedSelector returns a Selector that picks addresses at random
+// in proportion to weights.
+func NewWeightedSelector(weights map[string]int) Selector {
+	return &weightedSelector{weights: weights}
+}
+
+func (s *weightedSelector) Select(pool []string, _ SelectContext) (string, int) {
+
+	if len(pool) == 0 {
+		return "", -1
+	}
+
+	total := 0
+	for _, ip := range pool {
+		total += s.weight(ip)
+	}
+	if total == 0 {
+		idx := rand.Intn(len(pool))
+		return pool[idx], idx
+	}
+
+	pick := rand.Intn(total)
+	for idx, ip := range pool {
+		pick -= s.weight(ip)
+		if pick < 0 {
+			return ip, idx
+		}
+	}
+
+	return pool[len(pool)-1], len(pool) - 1
+}
+
+func (s *weightedSelector) weight(ip string) int {
+	if w, ok := s.weights[ip]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// p2cSelector implements "power of two choices": it samples two random
+// addresses and picks the one with the lower reported RTT, falling back
+// to a random pick when no RTT sample is available for either.
+type p2cSelector struct{}
+
+// NewP2CSelector returns a latency-aware Selector. RTT samples must be
+// reported via the resolver's ReportRTT method.
+func NewP2CSelector() Selector {
+	return p2cSelector{}
+}
+
+func (p2cSelector) Select(pool []string, ctx SelectContext) (string, int) {
+
+	if len(pool) == 0 {
+		return "", -1
+	}
+	if len(pool) == 1 || ctx.RTT == nil {
+		idx := rand.Intn(len(pool))
+		return pool[idx], idx
+	}
+
+	i := rand.Intn(len(pool))
+	j := rand.Intn(len(pool) - 1)
+	if j >= i {
+		j++
+	}
+
+	iRTT, iOK := ctx.RTT(pool[i])
+	jRTT, jOK := ctx.RTT(pool[j])
+
+	switch {
+	case iOK && jOK:
+		if jRTT < iRTT {
+			return pool[j], j
+		}
+		return pool[i], i
+	case iOK:
+		return pool[i], i
+	case jOK:
+		return pool[j], j
+	default:
+		return pool[i], i
+	}
+}
+
+// consistentHashReplicas is the number of ring points placed per pool
+// member. Higher counts spread a member's keys more evenly around the
+// ring at the cost of a larger per-call sort.
+const consistentHashReplicas = 100
+
+type ringPoint struct {
+	hash uint32
+	idx  int
+}
+
+// consistentHashSelector maps ctx.Key to the same pool member across
+// calls via an FNV-hashed ring (consistentHashReplicas points per
+// member), so that adding or removing one address from the pool only
+// remaps the keys that land in its vicinity on the ring, not the whole
+// keyspace the way a plain modulo hash would. The ring is cached and
+// only rebuilt when pool's membership actually changes between calls -
+// a Selector is reused across repeated GetIPForKey calls for the same
+// host, so this keeps the common case to a single FNV hash and binary
+// search instead of re-hashing and re-sorting the whole ring every time.
+type consistentHashSelector struct {
+	mu      sync.Mutex
+	poolKey string
+	ring    []ringPoint
+}
+
+// NewConsistentHashSelector returns a Selector that picks an address
+// deterministically from ctx.Key, falling back to random when no key is
+// given.
+func NewConsistentHashSelector() Selector {
+	return &consistentHashSelector{}
+}
+
+func (s *consistentHashSelector) Select(pool []string, ctx SelectContext) (string, int) {
+
+	if len(pool) == 0 {
+		return "", -1
+	}
+	if ctx.Key == "" {
+		idx := rand.Intn(len(pool))
+		return pool[idx], idx
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if poolKey := strings.Join(pool, ","); poolKey != s.poolKey {
+		s.ring = buildHashRing(pool)
+		s.poolKey = poolKey
+	}
+
+	keyHash := fnv32a(ctx.Key)
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= keyHash })
+	if i == len(s.ring) {
+		i = 0
+	}
+
+	return pool[s.ring[i].idx], s.ring[i].idx
+}
+
+// buildHashRing places consistentHashReplicas points per pool member on
+// the ring. The replica index is prefixed, not suffixed: FNV-1a's last
+// byte feeds straight into the final multiply, so strings that only
+// differ in their trailing byte (e.g. "ip#0", "ip#1", ...) produce
+// hashes spaced by an almost-constant multiple of the FNV prime instead
+// of spreading across the ring. Varying an early byte instead gives
+// every replica's hash time to fully diffuse through the rest of the
+// string.
+func buildHashRing(pool []string) []ringPoint {
+
+	ring := make([]ringPoint, 0, len(pool)*consistentHashReplicas)
+	for idx, ip := range pool {
+		for r := 0; r < consistentHashReplicas; r++ {
+			ring = append(ring, ringPoint{hash: fnv32a(fmt.Sprintf("%d-%s", r, ip)), idx: idx})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+
+	return ring
+}
+
+func fnv32a(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
@@ -0,0 +1,76 @@
+package resolver
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestBuildHashRingSortedAndSized(t *testing.T) {
+
+	pool := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+
+	ring := buildHashRing(pool)
+
+	if len(ring) != len(pool)*consistentHashReplicas {
+		t.Fatalf("got %d ring points, want %d", len(ring), len(pool)*consistentHashReplicas)
+	}
+	if !sort.SliceIsSorted(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash }) {
+		t.Fatalf("ring is not sorted by hash")
+	}
+}
+
+func TestConsistentHashSelectorRemapsOnlyAMinorityOnPoolChange(t *testing.T) {
+
+	const poolSize = 20
+	const numKeys = 500
+
+	pool := make([]string, poolSize)
+	for i := range pool {
+		pool[i] = fmt.Sprintf("10.0.0.%d", i+1)
+	}
+
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	sel := NewConsistentHashSelector()
+
+	before := make(map[string]string, numKeys)
+	for _, key := range keys {
+		ip, _ := sel.Select(pool, SelectContext{Key: key})
+		before[key] = ip
+	}
+
+	// Remove one member: only the keys that landed on its ring arc
+	// should remap, not the whole keyspace the way a modulo hash would.
+	shrunk := pool[:poolSize-1]
+
+	remapped := 0
+	for _, key := range keys {
+		ip, _ := sel.Select(shrunk, SelectContext{Key: key})
+		if ip != before[key] {
+			remapped++
+		}
+	}
+
+	if frac := float64(remapped) / float64(numKeys); frac > 0.25 {
+		t.Fatalf("removing 1 of %d pool members remapped %d/%d keys (%.0f%%), want a minority (<=25%%)",
+			poolSize, remapped, numKeys, frac*100)
+	}
+}
+
+func TestConsistentHashSelectorStableForUnchangedPool(t *testing.T) {
+
+	pool := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}
+
+	sel := NewConsistentHashSelector()
+
+	ip1, idx1 := sel.Select(pool, SelectContext{Key: "stable-key"})
+	ip2, idx2 := sel.Select(pool, SelectContext{Key: "stable-key"})
+
+	if ip1 != ip2 || idx1 != idx2 {
+		t.Fatalf("same key/pool selected different results across calls: (%s,%d) vs (%s,%d)", ip1, idx1, ip2, idx2)
+	}
+}
@@ -0,0 +1,203 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SRVTarget is a single decoded SRV record: the advertised host/port
+// plus its selection weight.
+type SRVTarget struct {
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	Host     string
+}
+
+// SRVResolver is implemented by Resolver backends that can also resolve
+// SRV records. Backends that can't (e.g. one built only for A/AAAA)
+// simply don't implement it, and AddSRV reports an error.
+type SRVResolver interface {
+	LookupSRV(name string) ([]SRVTarget, time.Duration, error)
+}
+
+// TXTResolver is implemented by Resolver backends that can also resolve
+// TXT records.
+type TXTResolver interface {
+	LookupTXT(name string) ([]string, time.Duration, error)
+}
+
+var errSRVUnsupported = errors.New("resolver: backend does not support SRV lookups")
+var errTXTUnsupported = errors.New("resolver: backend does not support TXT lookups")
+
+type srvRecord struct {
+	targets []SRVTarget
+	refresh chan struct{}
+}
+
+type txtRecord struct {
+	values  []string
+	refresh chan struct{}
+}
+
+// AddSRV starts polling the SRV record for _service._proto.name (e.g.
+// AddSRV("http", "tcp", "example.com") polls _http._tcp.example.com),
+// using the svc's default Resolver. Its targets are retrieved with
+// GetSRV(qname).
+func (s *svc) AddSRV(service, proto, name string) {
+
+	qname := fmt.Sprintf("_%s._%s.%s", service, proto, name)
+
+	s.srvMu.Lock()
+	if s.srv == nil {
+		s.srv = make(map[string]*srvRecord)
+	}
+	if _, ok := s.srv[qname]; ok {
+		s.srvMu.Unlock()
+		return
+	}
+	refresh := make(chan struct{}, 1)
+	s.srv[qname] = &srvRecord{refresh: refresh}
+	s.srvMu.Unlock()
+
+	go s.pollSRV(qname, refresh)
+}
+
+func (s *svc) pollSRV(qname string, refresh chan struct{}) {
+
+	s.log.Info().Println(s.tag, "start SRV resolver for:", qname)
+
+	for {
+		s.srvMu.RLock()
+		_, tracked := s.srv[qname]
+		s.srvMu.RUnlock()
+		if !tracked {
+			s.log.Error().Println(s.tag, "stop SRV resolver for:", qname)
+			return
+		}
+
+		sleep := s.ttlFloor
+
+		sr, ok := s.resolver.(SRVResolver)
+		if !ok {
+			s.log.Error().Println(s.tag, "resolve SRV", qname, "failed:", errSRVUnsupported)
+		} else {
+			targets, ttl, err := sr.LookupSRV(qname)
+			if err != nil {
+				s.log.Error().Println(s.tag, "resolve SRV", qname, "failed:", err)
+			} else {
+				s.updateSRV(qname, targets)
+				sleep = clampTTL(ttl, s.ttlFloor, s.ttlCeiling)
+			}
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-timer.C:
+		case <-refresh:
+			timer.Stop()
+		}
+	}
+}
+
+func (s *svc) updateSRV(qname string, targets []SRVTarget) {
+
+	s.srvMu.Lock()
+	r, ok := s.srv[qname]
+	if ok {
+		r.targets = targets
+	}
+	s.srvMu.Unlock()
+
+	if ok {
+		s.publish(qname, Event{Type: EventSRVChanged, Name: qname, SRV: targets})
+	}
+}
+
+// GetSRV returns the most recently resolved targets for qname (the
+// full "_service._proto.name" query name passed to AddSRV).
+func (s *svc) GetSRV(qname string) []SRVTarget {
+
+	s.srvMu.RLock()
+	defer s.srvMu.RUnlock()
+
+	if r, ok := s.srv[qname]; ok {
+		return r.targets
+	}
+
+	return nil
+}
+
+// AddTXT starts polling the TXT records for name. Its values are
+// retrieved with GetTXT(name).
+func (s *svc) AddTXT(name string) {
+
+	s.txtMu.Lock()
+	if s.txt == nil {
+		s.txt = make(map[string]*txtRecord)
+	}
+	if _, ok := s.txt[name]; ok {
+		s.txtMu.Unlock()
+		return
+	}
+	refresh := make(chan struct{}, 1)
+	s.txt[name] = &txtRecord{refresh: refresh}
+	s.txtMu.Unlock()
+
+	go s.pollTXT(name, refresh)
+}
+
+func (s *svc) pollTXT(name string, refresh chan struct{}) {
+
+	s.log.Info().Println(s.tag, "start TXT resolver for:", name)
+
+	for {
+		s.txtMu.RLock()
+		_, tracked := s.txt[name]
+		s.txtMu.RUnlock()
+		if !tracked {
+			s.log.Error().Println(s.tag, "stop TXT resolver for:", name)
+			return
+		}
+
+		sleep := s.ttlFloor
+
+		tr, ok := s.resolver.(TXTResolver)
+		if !ok {
+			s.log.Error().Println(s.tag, "resolve TXT", name, "failed:", errTXTUnsupported)
+		} else {
+			values, ttl, err := tr.LookupTXT(name)
+			if err != nil {
+				s.log.Error().Println(s.tag, "resolve TXT", name, "failed:", err)
+			} else {
+				s.txtMu.Lock()
+				if r, ok := s.txt[name]; ok {
+					r.values = values
+				}
+				s.txtMu.Unlock()
+				sleep = clampTTL(ttl, s.ttlFloor, s.ttlCeiling)
+			}
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-timer.C:
+		case <-refresh:
+			timer.Stop()
+		}
+	}
+}
+
+// GetTXT returns the most recently resolved TXT values for name.
+func (s *svc) GetTXT(name string) []string {
+
+	s.txtMu.RLock()
+	defer s.txtMu.RUnlock()
+
+	if r, ok := s.txt[name]; ok {
+		return r.values
+	}
+
+	return nil
+}